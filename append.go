@@ -0,0 +1,244 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Append extends the tree with one more leaf in O(log N) amortized time,
+// switching it into an append-only, Certificate-Transparency-style log as
+// described by RFC 6962: node hashes are domain-separated, with a 0x00
+// prefix for leaves and a 0x01 prefix for internal nodes, so a second
+// preimage can never be mistaken for a different kind of node. The first
+// call to Append or ConsistencyProof rebuilds this representation from the
+// tree's existing leaves (an O(N) one-off conversion); every subsequent
+// Append is O(log N). A tree that does not hold its original leaf data (one
+// returned by Load or IncrementalBuilder.Finalize) cannot be converted, and
+// both methods return an error for it.
+//
+// Once a tree is in log mode, Root() returns the RFC 6962 tree hash rather
+// than the hash produced by New/NewUsing, so proofs taken before and after
+// the first Append are not interchangeable.
+func (t *MerkleTree) Append(leaf []byte) error {
+	if t.frontier == nil {
+		if len(t.rawData) != t.data {
+			return errors.New("tree holds no leaf data to rebuild an append-only log from")
+		}
+		t.initLog()
+	}
+
+	hashed := ct6962LeafHash(t.hash, leaf)
+	t.leaves = append(t.leaves, hashed)
+	t.size++
+	t.appendToFrontier(hashed)
+	t.logRoot = t.frontierRoot()
+	return nil
+}
+
+// ConsistencyProof returns the minimal set of hashes proving that the tree
+// at newSize is an extension of the tree at oldSize, i.e. that no leaf
+// present at oldSize has been altered or reordered. VerifyConsistencyProof
+// checks it against the root hashes recorded at both sizes.
+func (t *MerkleTree) ConsistencyProof(oldSize, newSize uint64) ([][]byte, error) {
+	if t.frontier == nil {
+		if len(t.rawData) != t.data {
+			return nil, errors.New("tree holds no leaf data to rebuild an append-only log from")
+		}
+		t.initLog()
+	}
+	if oldSize == 0 {
+		return nil, errors.New("consistency proof requires a non-empty old tree")
+	}
+	if oldSize > newSize {
+		return nil, errors.New("old size must not exceed new size")
+	}
+	if newSize > t.size {
+		return nil, errors.New("new size exceeds the number of leaves appended")
+	}
+	if oldSize == newSize {
+		return [][]byte{}, nil
+	}
+
+	return t.subProof(oldSize, t.leaves[:newSize], true), nil
+}
+
+// VerifyConsistencyProof checks that newRoot is a valid extension of oldRoot,
+// i.e. that the tree at newSize shares oldRoot as the root of its first
+// oldSize leaves, without needing access to any of the underlying leaf data.
+func VerifyConsistencyProof(hash HashType, oldSize, newSize uint64, oldRoot, newRoot []byte, proof [][]byte) (bool, error) {
+	if oldSize == 0 || oldSize > newSize {
+		return false, errors.New("invalid tree sizes for consistency proof")
+	}
+	if oldSize == newSize {
+		return len(proof) == 0 && bytes.Equal(oldRoot, newRoot), nil
+	}
+
+	oldPartial, total, rest, err := verifySubProof(hash, oldSize, newSize, true, oldRoot, proof)
+	if err != nil {
+		return false, err
+	}
+	if len(rest) != 0 {
+		return false, errors.New("consistency proof has unconsumed hashes")
+	}
+
+	return bytes.Equal(oldPartial, oldRoot) && bytes.Equal(total, newRoot), nil
+}
+
+// verifySubProof mirrors subProof's recursion over sizes alone (it never
+// needs leaf data): it returns the subtree root for the old tree's overlap
+// with the current [0,n) range ("oldPartial"), the full subtree root for the
+// entire current range ("total"), and the unconsumed tail of proof. seed is
+// substituted for oldPartial at the one base case where the old tree aligns
+// exactly with a subtree boundary and so needs no proof of its own — the
+// RFC 6962 "fn == 0" special case.
+func verifySubProof(hash HashType, m, n uint64, b bool, seed []byte, proof [][]byte) ([]byte, []byte, [][]byte, error) {
+	if m == n {
+		if b {
+			return seed, seed, proof, nil
+		}
+		if len(proof) == 0 {
+			return nil, nil, nil, errors.New("consistency proof is missing a hash")
+		}
+		return proof[0], proof[0], proof[1:], nil
+	}
+
+	k := largestPowerOfTwoLessThan(n)
+	if m <= k {
+		oldPartial, leftTotal, rest, err := verifySubProof(hash, m, k, b, seed, proof)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if len(rest) == 0 {
+			return nil, nil, nil, errors.New("consistency proof is missing a hash")
+		}
+		rightTotal := rest[0]
+		return oldPartial, ct6962NodeHash(hash, leftTotal, rightTotal), rest[1:], nil
+	}
+
+	oldPartial, rightTotal, rest, err := verifySubProof(hash, m-k, n-k, false, seed, proof)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(rest) == 0 {
+		return nil, nil, nil, errors.New("consistency proof is missing a hash")
+	}
+	leftTotal := rest[0]
+	return ct6962NodeHash(hash, leftTotal, oldPartial), ct6962NodeHash(hash, leftTotal, rightTotal), rest[1:], nil
+}
+
+// initLog rebuilds the append-only frontier representation from the tree's
+// original leaves, so Append and ConsistencyProof can be used on a tree that
+// was first built with New or NewUsing.
+func (t *MerkleTree) initLog() {
+	t.frontier = make([][]byte, 0)
+	t.leaves = make([][]byte, 0, len(t.rawData))
+	t.size = 0
+	for _, datum := range t.rawData {
+		hashed := ct6962LeafHash(t.hash, datum)
+		t.leaves = append(t.leaves, hashed)
+		t.size++
+		t.appendToFrontier(hashed)
+	}
+	t.logRoot = t.frontierRoot()
+}
+
+// appendToFrontier folds node into the frontier of pending perfect subtree
+// roots, merging pairs as they complete.
+func (t *MerkleTree) appendToFrontier(node []byte) {
+	level := 0
+	for {
+		if level == len(t.frontier) {
+			t.frontier = append(t.frontier, nil)
+		}
+		if t.frontier[level] == nil {
+			t.frontier[level] = node
+			return
+		}
+		node = ct6962NodeHash(t.hash, t.frontier[level], node)
+		t.frontier[level] = nil
+		level++
+	}
+}
+
+// frontierRoot folds the current frontier down into a single root hash.
+func (t *MerkleTree) frontierRoot() []byte {
+	var node []byte
+	have := false
+	for level := 0; level < len(t.frontier); level++ {
+		if t.frontier[level] == nil {
+			continue
+		}
+		if !have {
+			node = t.frontier[level]
+			have = true
+			continue
+		}
+		node = ct6962NodeHash(t.hash, t.frontier[level], node)
+	}
+	return node
+}
+
+// subProof implements RFC 6962's SUBPROOF algorithm (section 2.1.2),
+// recomputing subtree roots with mth directly rather than from a cache; this
+// keeps ConsistencyProof simple at the cost of O(N) work per call.
+func (t *MerkleTree) subProof(m uint64, leaves [][]byte, b bool) [][]byte {
+	n := uint64(len(leaves))
+	if m == n {
+		if b {
+			return [][]byte{}
+		}
+		return [][]byte{mth(t.hash, leaves)}
+	}
+
+	k := largestPowerOfTwoLessThan(n)
+	if m <= k {
+		return append(t.subProof(m, leaves[:k], b), mth(t.hash, leaves[k:]))
+	}
+	return append(t.subProof(m-k, leaves[k:], false), mth(t.hash, leaves[:k]))
+}
+
+// mth is RFC 6962's Merkle Tree Hash, applied to already leaf-hashed data.
+func mth(hash HashType, leaves [][]byte) []byte {
+	n := len(leaves)
+	if n == 1 {
+		return leaves[0]
+	}
+	k := largestPowerOfTwoLessThan(uint64(n))
+	left := mth(hash, leaves[:k])
+	right := mth(hash, leaves[k:])
+	return ct6962NodeHash(hash, left, right)
+}
+
+// largestPowerOfTwoLessThan returns the largest power of two strictly less
+// than n.
+func largestPowerOfTwoLessThan(n uint64) uint64 {
+	k := uint64(1)
+	for k*2 < n {
+		k *= 2
+	}
+	return k
+}
+
+// ct6962LeafHash hashes a leaf with RFC 6962's leaf domain separator.
+func ct6962LeafHash(hash HashType, leaf []byte) []byte {
+	return hash.Hash([]byte{0x00}, leaf)
+}
+
+// ct6962NodeHash hashes an internal node with RFC 6962's node domain
+// separator.
+func ct6962NodeHash(hash HashType, left, right []byte) []byte {
+	return hash.Hash([]byte{0x01}, left, right)
+}
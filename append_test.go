@@ -0,0 +1,55 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-merkletree/blake2b"
+)
+
+func TestConsistencyProof(t *testing.T) {
+	hash := blake2b.New()
+	tree, err := NewUsing([][]byte{[]byte("Foo")}, hash, false)
+	assert.Nil(t, err)
+
+	assert.Nil(t, tree.Append([]byte("Bar")))
+	oldRoot := append([]byte(nil), tree.Root()...)
+
+	for _, leaf := range [][]byte{[]byte("Baz"), []byte("Qux"), []byte("Quux")} {
+		assert.Nil(t, tree.Append(leaf))
+	}
+	newRoot := tree.Root()
+
+	proof, err := tree.ConsistencyProof(2, 5)
+	assert.Nil(t, err)
+
+	verified, err := VerifyConsistencyProof(hash, 2, 5, oldRoot, newRoot, proof)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+}
+
+func TestAppendDisablesStaticTreeMethods(t *testing.T) {
+	tree, err := NewUsing([][]byte{[]byte("Foo"), []byte("Bar")}, blake2b.New(), false)
+	assert.Nil(t, err)
+
+	assert.Nil(t, tree.Append([]byte("Baz")))
+
+	_, err = tree.GenerateProof([]byte("Foo"))
+	assert.NotNil(t, err)
+
+	assert.NotNil(t, tree.Save(&bytes.Buffer{}))
+}
@@ -0,0 +1,81 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkletree
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// DOT returns the GraphViz DOT representation of the tree, suitable for
+// rendering with `dot -Tpng`. keyFormatter is used to render the original
+// leaf data and hashFormatter to render node hashes; both default to a
+// TruncatedHexFormatter when nil.
+func (t *MerkleTree) DOT(keyFormatter Formatter, hashFormatter Formatter) string {
+	if keyFormatter == nil {
+		keyFormatter = new(TruncatedHexFormatter)
+	}
+	if hashFormatter == nil {
+		hashFormatter = new(TruncatedHexFormatter)
+	}
+
+	branches := t.nodes.Len() / 2
+
+	var b strings.Builder
+	b.WriteString("digraph MerkleTree {rankdir = TB;")
+	b.WriteString("node [shape=rectangle margin=\"0.2,0.2\"];")
+
+	for i := uint64(0); i < branches; i++ {
+		nodeIndex := branches + i
+		if i < uint64(t.data) {
+			fmt.Fprintf(&b, "%q [shape=oval];", keyFormatter.Format(t.leafDatum(i)))
+			if t.salt {
+				indexBytes := make([]byte, 4)
+				binary.BigEndian.PutUint32(indexBytes, uint32(i))
+				fmt.Fprintf(&b, "%q->%d [label=\"+%x\"];", keyFormatter.Format(t.leafDatum(i)), nodeIndex, indexBytes)
+			} else {
+				fmt.Fprintf(&b, "%q->%d;", keyFormatter.Format(t.leafDatum(i)), nodeIndex)
+			}
+		}
+		fmt.Fprintf(&b, "%d [label=%q];", nodeIndex, hashFormatter.Format(t.nodes.Get(nodeIndex)))
+		if i > 0 {
+			fmt.Fprintf(&b, "%d->%d [style=invisible arrowhead=none];", branches+i-1, nodeIndex)
+		}
+		if nodeIndex > 1 {
+			fmt.Fprintf(&b, "%d->%d;", nodeIndex, nodeIndex/2)
+		}
+	}
+
+	b.WriteString("{rank=same;")
+	for i := branches; i < branches*2; i++ {
+		fmt.Fprintf(&b, "%d;", i)
+	}
+	b.WriteString("};")
+
+	for i := branches - 1; i > 0; i-- {
+		fmt.Fprintf(&b, "%d [label=%q];", i, hashFormatter.Format(t.nodes.Get(i)))
+		if i > 1 {
+			fmt.Fprintf(&b, "%d->%d;", i, i/2)
+		}
+	}
+
+	b.WriteString("}")
+	return b.String()
+}
+
+// leafDatum returns the original data supplied for leaf i.
+func (t *MerkleTree) leafDatum(i uint64) []byte {
+	return t.rawData[i]
+}
@@ -0,0 +1,118 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkletree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/wealdtech/go-merkletree/keccak256"
+)
+
+// EthereumProof is a Merkle proof of inclusion laid out so that it verifies
+// against the OpenZeppelin MerkleProof.verify Solidity contract: internal
+// nodes are hashed as keccak256(min(a,b) || max(a,b)), so the proof carries
+// no direction bits.
+type EthereumProof [][32]byte
+
+// MarshalJSON renders the proof as a JSON array of hex-prefixed 0x… strings,
+// for consumption by JS/TS airdrop tooling.
+func (p EthereumProof) MarshalJSON() ([]byte, error) {
+	hexes := make([]string, len(p))
+	for i, hash := range p {
+		hexes[i] = fmt.Sprintf("0x%x", hash)
+	}
+	return json.Marshal(hexes)
+}
+
+// NewEthereum creates a Merkle tree using keccak256, with every internal
+// node hashed over its two children in sorted order, so it is suitable for
+// generating proofs that verify against OpenZeppelin's MerkleProof.verify.
+// data should already be the keccak256(abi.encode(...)) hash of each leaf,
+// following the convention used by Solidity airdrop contracts.
+func NewEthereum(data [][]byte) (*MerkleTree, error) {
+	if len(data) == 0 {
+		return nil, errors.New("tree must have at least 1 piece of data")
+	}
+
+	hash := keccak256.New()
+	branches := nextPowOf2(uint64(len(data)))
+	nodes := newInMemoryNodeStore(make([][]byte, branches*2))
+
+	for i, datum := range data {
+		nodes.Set(branches+uint64(i), hash.Hash(datum))
+	}
+	for i := uint64(len(data)); i < branches; i++ {
+		nodes.Set(branches+i, make([]byte, hash.HashLength()))
+	}
+	for i := branches - 1; i > 0; i-- {
+		nodes.Set(i, hashEthereumPair(hash, nodes.Get(i*2), nodes.Get(i*2+1)))
+	}
+
+	return &MerkleTree{
+		nodes:   nodes,
+		data:    len(data),
+		rawData: data,
+		hash:    hash,
+	}, nil
+}
+
+// hashEthereumPair hashes two sibling nodes in sorted order, as required by
+// OpenZeppelin's MerkleProof.verify.
+func hashEthereumPair(hash HashType, a, b []byte) []byte {
+	if bytes.Compare(a, b) <= 0 {
+		return hash.Hash(a, b)
+	}
+	return hash.Hash(b, a)
+}
+
+// GenerateEthereumProof generates a proof for leaf that verifies against the
+// OpenZeppelin MerkleProof.verify Solidity contract.
+func (t *MerkleTree) GenerateEthereumProof(leaf []byte) (EthereumProof, error) {
+	index, err := t.indexOf(leaf)
+	if err != nil {
+		return nil, err
+	}
+
+	branches := t.nodes.Len() / 2
+	proof := make(EthereumProof, 0)
+
+	nodeIndex := branches + index
+	for nodeIndex > 1 {
+		siblingIndex := nodeIndex ^ 1
+		sibling := t.nodes.Get(siblingIndex)
+		if len(sibling) != 32 {
+			return nil, errors.New("ethereum proofs require a 32-byte hash function")
+		}
+		var hash [32]byte
+		copy(hash[:], sibling)
+		proof = append(proof, hash)
+		nodeIndex /= 2
+	}
+
+	return proof, nil
+}
+
+// VerifyEthereumProof verifies an EthereumProof for leaf against root, using
+// the sorted-pair hashing convention that OpenZeppelin's MerkleProof.verify
+// relies on.
+func VerifyEthereumProof(hash HashType, leaf []byte, proof EthereumProof, root []byte) (bool, error) {
+	node := hash.Hash(leaf)
+	for _, sibling := range proof {
+		node = hashEthereumPair(hash, node, sibling[:])
+	}
+	return bytes.Equal(node, root), nil
+}
@@ -0,0 +1,41 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkletree
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEthereumProofRoundTrip(t *testing.T) {
+	data := [][]byte{
+		[]byte("Foo"), []byte("Bar"), []byte("Baz"), []byte("Qux"),
+	}
+
+	tree, err := NewEthereum(data)
+	assert.Nil(t, err)
+
+	proof, err := tree.GenerateEthereumProof(data[2])
+	assert.Nil(t, err)
+
+	verified, err := VerifyEthereumProof(tree.hash, data[2], proof, tree.Root())
+	assert.Nil(t, err)
+	assert.True(t, verified)
+
+	out, err := json.Marshal(proof)
+	assert.Nil(t, err)
+	assert.Contains(t, string(out), "0x")
+}
@@ -0,0 +1,51 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkletree
+
+import "fmt"
+
+// Formatter formats a piece of data for textual output, for example when
+// rendering a tree's DOT representation.
+type Formatter interface {
+	// Format returns a textual representation of the given bytes.
+	Format([]byte) string
+}
+
+// StringFormatter formats bytes as the plain string they represent.
+type StringFormatter struct{}
+
+// Format returns the bytes as a string.
+func (f *StringFormatter) Format(bytes []byte) string {
+	return string(bytes)
+}
+
+// HexFormatter formats bytes as a full hex string.
+type HexFormatter struct{}
+
+// Format returns the bytes as a hex string.
+func (f *HexFormatter) Format(bytes []byte) string {
+	return fmt.Sprintf("%x", bytes)
+}
+
+// TruncatedHexFormatter formats bytes as a shortened hex string, suitable for
+// use in diagrams where the full value would be unwieldy. It is the default
+// formatter used when none is supplied.
+type TruncatedHexFormatter struct{}
+
+// Format returns the first and last four characters of the hex
+// representation of the bytes, separated by an ellipsis.
+func (f *TruncatedHexFormatter) Format(bytes []byte) string {
+	input := fmt.Sprintf("%x", bytes)
+	return fmt.Sprintf("%s…%s", input[:4], input[len(input)-4:])
+}
@@ -0,0 +1,23 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkletree
+
+// HashType defines the interface that a hash type must fulfil to be used in
+// the Merkle tree.
+type HashType interface {
+	// Hash generates a hash from a concatenation of the given pieces of data.
+	Hash(data ...[]byte) []byte
+	// HashLength provides the length of the hash, in bytes.
+	HashLength() int
+}
@@ -0,0 +1,250 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkletree
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// IncrementalBuilder builds a MerkleTree one leaf at a time, holding only the
+// O(log N) frontier of unmerged right-spine nodes in memory at any point.
+// This makes it suitable for datasets with millions of leaves that cannot be
+// held as a single [][]byte slice, unlike NewUsing.
+type IncrementalBuilder struct {
+	hash HashType
+	salt bool
+	// count is the number of leaves appended so far.
+	count uint64
+	// frontier[level] holds the single node waiting to be merged with its
+	// right sibling at that level, or nil if the level currently has no
+	// pending node.
+	frontier [][]byte
+	// spill, if set, receives every node computed while folding a leaf into
+	// the frontier - whether or not it ends up resting there - tagged with
+	// its level and position, so LoadFromSpill can later reconstruct an
+	// authentication path without holding the whole tree in RAM.
+	spill io.Writer
+}
+
+// NewIncrementalBuilder creates an IncrementalBuilder using the given hash
+// type. If spill is non-nil, every node computed while appending leaves is
+// written to it as it is produced; the zero-padded nodes Finalize computes
+// are not.
+func NewIncrementalBuilder(hash HashType, salt bool, spill io.Writer) *IncrementalBuilder {
+	return &IncrementalBuilder{
+		hash:  hash,
+		salt:  salt,
+		spill: spill,
+	}
+}
+
+// Append adds a single leaf to the tree being built.
+func (b *IncrementalBuilder) Append(leaf []byte) error {
+	position := b.count
+	node := leafHash(b.hash, leaf, int(position), b.salt)
+	b.count++
+	return b.fold(position, node, true)
+}
+
+// AppendBatch adds a sequence of leaves in order, equivalent to calling
+// Append for each in turn.
+func (b *IncrementalBuilder) AppendBatch(leaves [][]byte) error {
+	for _, leaf := range leaves {
+		if err := b.Append(leaf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fold merges node, which sits at the given position within its level, into
+// the frontier, combining it with whatever is already waiting there and
+// carrying the result upward until it reaches a level with no pending node.
+// If spill is true, every node it passes through (not just the one that ends
+// up resting) is written to the configured spill writer.
+func (b *IncrementalBuilder) fold(position uint64, node []byte, spill bool) error {
+	level := 0
+	for {
+		if level == len(b.frontier) {
+			b.frontier = append(b.frontier, nil)
+		}
+		if spill {
+			if err := b.spillNode(uint32(level), position, node); err != nil {
+				return err
+			}
+		}
+		if b.frontier[level] == nil {
+			b.frontier[level] = node
+			return nil
+		}
+
+		node = b.hash.Hash(b.frontier[level], node)
+		b.frontier[level] = nil
+		position >>= 1
+		level++
+	}
+}
+
+// spillNode writes node, found at (level, position), to the configured spill
+// writer, if any.
+func (b *IncrementalBuilder) spillNode(level uint32, position uint64, node []byte) error {
+	if b.spill == nil {
+		return nil
+	}
+	header := make([]byte, 4+8)
+	binary.BigEndian.PutUint32(header[0:4], level)
+	binary.BigEndian.PutUint64(header[4:12], position)
+	if _, err := b.spill.Write(header); err != nil {
+		return err
+	}
+	_, err := b.spill.Write(node)
+	return err
+}
+
+// Finalize completes the tree, folding the frontier against the zero hashes
+// of the missing leaves needed to reach a full power-of-two tree - exactly
+// as NewUsing pads a short leaf row - and returns the resulting MerkleTree.
+// The returned tree's Root and String work as usual, but since the builder
+// never retains the underlying nodes array, its GenerateProof cannot be
+// used; open the builder's spill with LoadFromSpill to generate proofs.
+func (b *IncrementalBuilder) Finalize() (*MerkleTree, error) {
+	if b.count == 0 {
+		return nil, errors.New("tree must have at least 1 piece of data")
+	}
+
+	branches := nextPowOf2(b.count)
+	zero := make([]byte, b.hash.HashLength())
+	for position := b.count; position < branches; position++ {
+		if err := b.fold(position, zero, false); err != nil {
+			return nil, err
+		}
+	}
+
+	var root []byte
+	for level := 0; level < len(b.frontier); level++ {
+		if b.frontier[level] != nil {
+			root = b.frontier[level]
+		}
+	}
+
+	nodes := make([][]byte, branches*2)
+	nodes[1] = root
+
+	return &MerkleTree{
+		nodes: newInMemoryNodeStore(nodes),
+		data:  int(b.count),
+		hash:  b.hash,
+		salt:  b.salt,
+	}, nil
+}
+
+// LoadFromSpill reopens the node index previously written by an
+// IncrementalBuilder's spill writer, without holding the whole tree in
+// memory. It supports GenerateProof(index) by scanning the spill for each
+// sibling on the path to the requested leaf, recomputing any that fall in a
+// partially zero-padded subtree on the fly.
+type LoadFromSpill struct {
+	hash  HashType
+	r     io.ReaderAt
+	size  int64
+	count uint64
+}
+
+// OpenSpill wraps a spilled node index, built from count leaves, for later
+// proof reconstruction.
+func OpenSpill(r io.ReaderAt, size int64, count uint64, hash HashType) *LoadFromSpill {
+	return &LoadFromSpill{hash: hash, r: r, size: size, count: count}
+}
+
+// GenerateProof scans the spilled node index for the siblings needed to
+// prove membership of the leaf at index, padding with zero hashes exactly as
+// Finalize does for any subtree that was never completed.
+func (s *LoadFromSpill) GenerateProof(index uint64) (*Proof, error) {
+	branches := nextPowOf2(s.count)
+	if index >= branches {
+		return nil, errors.New("index out of range")
+	}
+
+	levels := 0
+	for p := branches; p > 1; p /= 2 {
+		levels++
+	}
+
+	hashes := make([][]byte, 0, levels)
+	for level := 0; level < levels; level++ {
+		siblingPosition := (index >> uint(level)) ^ 1
+		sibling, err := s.nodeAt(level, siblingPosition)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, sibling)
+	}
+
+	return &Proof{Hashes: hashes, Index: index}, nil
+}
+
+// nodeAt returns the hash of the subtree at (level, position), which covers
+// leaves [position*2^level, (position+1)*2^level). A subtree entirely beyond
+// the leaves actually appended collapses to its zero hash; one entirely
+// within them was completed while appending and so was spilled exactly once;
+// one straddling the boundary is recomputed from its two children.
+func (s *LoadFromSpill) nodeAt(level int, position uint64) ([]byte, error) {
+	rangeStart := position << uint(level)
+	rangeEnd := rangeStart + (uint64(1) << uint(level))
+
+	if rangeStart >= s.count {
+		return zeroHashAtLevel(s.hash, level), nil
+	}
+	if rangeEnd <= s.count {
+		return s.readSpilledNode(level, position)
+	}
+
+	left, err := s.nodeAt(level-1, position*2)
+	if err != nil {
+		return nil, err
+	}
+	right, err := s.nodeAt(level-1, position*2+1)
+	if err != nil {
+		return nil, err
+	}
+	return s.hash.Hash(left, right), nil
+}
+
+// readSpilledNode scans the spill for the node recorded at (level, position).
+func (s *LoadFromSpill) readSpilledNode(level int, position uint64) ([]byte, error) {
+	recordLen := int64(4 + 8 + s.hash.HashLength())
+	buf := make([]byte, recordLen)
+	for offset := int64(0); offset < s.size; offset += recordLen {
+		if _, err := s.r.ReadAt(buf, offset); err != nil {
+			return nil, err
+		}
+		recLevel := binary.BigEndian.Uint32(buf[0:4])
+		recPosition := binary.BigEndian.Uint64(buf[4:12])
+		if recLevel == uint32(level) && recPosition == position {
+			return append([]byte(nil), buf[12:]...), nil
+		}
+	}
+	return nil, errors.New("spill is missing a node required for this proof")
+}
+
+// zeroHashAtLevel returns the hash of an empty subtree of 2^level leaves.
+func zeroHashAtLevel(hash HashType, level int) []byte {
+	zero := make([]byte, hash.HashLength())
+	for i := 0; i < level; i++ {
+		zero = hash.Hash(zero, zero)
+	}
+	return zero
+}
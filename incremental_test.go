@@ -0,0 +1,65 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-merkletree/blake2b"
+)
+
+func TestIncrementalBuilderMatchesNewUsing(t *testing.T) {
+	// An odd, non-power-of-two leaf count exercises Finalize's zero padding.
+	data := [][]byte{
+		[]byte("Foo"), []byte("Bar"), []byte("Baz"), []byte("Qux"), []byte("Quux"),
+	}
+
+	want, err := NewUsing(data, blake2b.New(), false)
+	assert.Nil(t, err)
+
+	builder := NewIncrementalBuilder(blake2b.New(), false, nil)
+	assert.Nil(t, builder.AppendBatch(data))
+	got, err := builder.Finalize()
+	assert.Nil(t, err)
+
+	assert.Equal(t, want.Root(), got.Root())
+}
+
+func TestLoadFromSpillGeneratesValidProofs(t *testing.T) {
+	data := [][]byte{
+		[]byte("Foo"), []byte("Bar"), []byte("Baz"), []byte("Qux"), []byte("Quux"),
+	}
+	hash := blake2b.New()
+
+	tree, err := NewUsing(data, hash, false)
+	assert.Nil(t, err)
+
+	var spill bytes.Buffer
+	builder := NewIncrementalBuilder(hash, false, &spill)
+	assert.Nil(t, builder.AppendBatch(data))
+
+	spilled := spill.Bytes()
+	loader := OpenSpill(bytes.NewReader(spilled), int64(len(spilled)), uint64(len(data)), hash)
+
+	for i, datum := range data {
+		proof, err := loader.GenerateProof(uint64(i))
+		assert.Nil(t, err)
+
+		verified, err := VerifyProof(hash, false, datum, proof, tree.Root())
+		assert.Nil(t, err)
+		assert.True(t, verified, "leaf %d did not verify", i)
+	}
+}
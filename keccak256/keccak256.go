@@ -0,0 +1,44 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package keccak256 provides a Keccak-256 hashing type for use with
+// go-merkletree. It is primarily useful when proofs need to be verified
+// on-chain, as Keccak-256 is the hash function used by the EVM.
+package keccak256
+
+import (
+	"golang.org/x/crypto/sha3"
+)
+
+// Keccak256 is the Keccak-256 hashing method.
+type Keccak256 struct{}
+
+// New creates a new Keccak-256 hashing method.
+func New() *Keccak256 {
+	return &Keccak256{}
+}
+
+// Hash generates a Keccak-256 hash of the concatenation of the supplied
+// data.
+func (h *Keccak256) Hash(data ...[]byte) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	for _, datum := range data {
+		hash.Write(datum)
+	}
+	return hash.Sum(nil)
+}
+
+// HashLength provides the length of the hash in bytes.
+func (h *Keccak256) HashLength() int {
+	return 32
+}
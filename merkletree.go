@@ -0,0 +1,199 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package merkletree provides a simple implementation of a Merkle tree, used
+// to generate and verify proofs that a piece of data is a member of a set
+// without requiring the full set to be known.
+package merkletree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/wealdtech/go-merkletree/blake2b"
+)
+
+// MerkleTree is the top-level structure for the Merkle tree.
+type MerkleTree struct {
+	// nodes holds the leaf and branch nodes of the tree behind a NodeStore,
+	// so they can be kept in memory or paged from disk. Node 1 is the root;
+	// node i's children are at 2i and 2i+1.
+	nodes NodeStore
+	// data is the number of leaves actually supplied when the tree was built
+	// (the node array itself is padded out to the next power of two).
+	data int
+	// rawData retains the original leaf data, used when rendering the tree.
+	rawData [][]byte
+	// hash is the hash function used to build the tree.
+	hash HashType
+	// salt states if the leaf data should be salted with its index before
+	// hashing.
+	salt bool
+
+	// frontier, leaves, size and logRoot back the append-only,
+	// Certificate-Transparency-style mode entered by the first call to
+	// Append or ConsistencyProof; see append.go. frontier is nil until then.
+	frontier [][]byte
+	leaves   [][]byte
+	size     uint64
+	logRoot  []byte
+}
+
+// New creates a new Merkle tree using the default hash type (BLAKE2b).
+func New(data [][]byte) (*MerkleTree, error) {
+	return NewUsing(data, blake2b.New(), false)
+}
+
+// NewUsing creates a new Merkle tree using the provided hash type. If salt is
+// true then each piece of data is salted with its index before hashing.
+func NewUsing(data [][]byte, hash HashType, salt bool) (*MerkleTree, error) {
+	if len(data) == 0 {
+		return nil, errors.New("tree must have at least 1 piece of data")
+	}
+
+	branches := nextPowOf2(uint64(len(data)))
+	nodes := newInMemoryNodeStore(make([][]byte, branches*2))
+
+	for i := range data {
+		nodes.Set(branches+uint64(i), leafHash(hash, data[i], i, salt))
+	}
+	for i := uint64(len(data)); i < branches; i++ {
+		nodes.Set(branches+i, make([]byte, hash.HashLength()))
+	}
+
+	for i := branches - 1; i > 0; i-- {
+		nodes.Set(i, hash.Hash(nodes.Get(i*2), nodes.Get(i*2+1)))
+	}
+
+	return &MerkleTree{
+		nodes:   nodes,
+		data:    len(data),
+		rawData: data,
+		hash:    hash,
+		salt:    salt,
+	}, nil
+}
+
+// NewUsingStore creates a new Merkle tree exactly as NewUsing does, but
+// writes its nodes into the supplied NodeStore instead of an in-memory
+// slice. This allows a FileNodeStore or similar to be used so that trees too
+// large to fit in RAM can still be built.
+func NewUsingStore(data [][]byte, hash HashType, salt bool, store NodeStore) (*MerkleTree, error) {
+	if len(data) == 0 {
+		return nil, errors.New("tree must have at least 1 piece of data")
+	}
+	branches := nextPowOf2(uint64(len(data)))
+	if store.Len() != branches*2 {
+		return nil, errors.New("node store is not sized for this data")
+	}
+
+	for i := range data {
+		store.Set(branches+uint64(i), leafHash(hash, data[i], i, salt))
+	}
+	for i := uint64(len(data)); i < branches; i++ {
+		store.Set(branches+i, make([]byte, hash.HashLength()))
+	}
+	for i := branches - 1; i > 0; i-- {
+		store.Set(i, hash.Hash(store.Get(i*2), store.Get(i*2+1)))
+	}
+
+	return &MerkleTree{
+		nodes:   store,
+		data:    len(data),
+		rawData: data,
+		hash:    hash,
+		salt:    salt,
+	}, nil
+}
+
+// leafHash hashes a single piece of leaf data, salting it with its index
+// first if required.
+func leafHash(hash HashType, datum []byte, index int, salt bool) []byte {
+	if salt {
+		indexBytes := make([]byte, 4)
+		binary.BigEndian.PutUint32(indexBytes, uint32(index))
+		return hash.Hash(datum, indexBytes)
+	}
+	return hash.Hash(datum)
+}
+
+// Root returns the Merkle root (the hash at the apex of the tree). Once the
+// tree has entered append-only log mode (see Append), this returns the RFC
+// 6962 tree hash instead of the nodes built by New/NewUsing.
+func (t *MerkleTree) Root() []byte {
+	if t.frontier != nil {
+		return t.logRoot
+	}
+	return t.nodes.Get(1)
+}
+
+// Close releases any resources (such as an open file) held by the tree's
+// underlying NodeStore.
+func (t *MerkleTree) Close() error {
+	return t.nodes.Close()
+}
+
+// String implements the stringer interface, returning the hex
+// representation of the root hash.
+func (t *MerkleTree) String() string {
+	return fmt.Sprintf("%x", t.Root())
+}
+
+// nextPowOf2 returns the smallest power of two that is >= v, with a minimum
+// of 1.
+func nextPowOf2(v uint64) uint64 {
+	if v == 0 {
+		return 1
+	}
+	v--
+	v |= v >> 1
+	v |= v >> 2
+	v |= v >> 4
+	v |= v >> 8
+	v |= v >> 16
+	v |= v >> 32
+	v++
+	return v
+}
+
+// indexOf returns the index of the leaf node holding datum, or an error if it
+// cannot be found.
+func (t *MerkleTree) indexOf(datum []byte) (uint64, error) {
+	if err := t.requireStaticTree(); err != nil {
+		return 0, err
+	}
+
+	branches := t.nodes.Len() / 2
+	for i := 0; i < t.data; i++ {
+		hash := leafHash(t.hash, datum, i, t.salt)
+		if bytes.Equal(hash, t.nodes.Get(branches+uint64(i))) {
+			return uint64(i), nil
+		}
+	}
+	return 0, errors.New("data not found")
+}
+
+// requireStaticTree returns an error if the tree has entered append-only log
+// mode (see Append), since t.nodes then no longer reflects the leaves Root()
+// hashes over: GenerateProof, Save and the other methods that read t.nodes
+// directly would silently produce proofs and serializations for a tree that
+// no longer exists. Use Append/ConsistencyProof to work with a log-mode tree
+// instead.
+func (t *MerkleTree) requireStaticTree() error {
+	if t.frontier != nil {
+		return errors.New("tree is in append-only log mode; t.nodes no longer reflects Root()")
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+)
+
+// MultiProof is a single proof that opens several leaves against one root at
+// once. Unlike generating a Proof per leaf, it omits any internal node that
+// can be derived from the requested leaves themselves, so its size grows
+// with log(N/k) rather than log(N) per leaf.
+type MultiProof struct {
+	// Indices are the leaf indices being proven, in the order supplied to
+	// GenerateMultiProof.
+	Indices []uint64
+	// NumLeaves is the number of leaf slots in the tree the proof was
+	// generated from (the data length padded up to a power of two), needed
+	// by the verifier to know where the bottom level of the tree starts.
+	NumLeaves uint64
+	// Hashes holds the sibling hashes that cannot be derived from the
+	// requested leaves, in the deterministic order they are consumed during
+	// verification: level by level, ascending node index within a level.
+	Hashes [][]byte
+}
+
+// GenerateMultiProof generates a proof that the leaves at indices are all
+// present in the tree, sharing any internal node their paths have in
+// common.
+func (t *MerkleTree) GenerateMultiProof(indices []uint64) (*MultiProof, error) {
+	if err := t.requireStaticTree(); err != nil {
+		return nil, err
+	}
+	if len(indices) == 0 {
+		return nil, errors.New("no indices supplied")
+	}
+
+	branches := t.nodes.Len() / 2
+	known := make(map[uint64]bool, len(indices))
+	for _, index := range indices {
+		if index >= branches {
+			return nil, errors.New("index out of range")
+		}
+		known[branches+index] = true
+	}
+
+	hashes := make([][]byte, 0)
+	levelStart := branches
+	for levelStart > 1 {
+		levelNodes := sortedKeysInRange(known, levelStart, levelStart*2)
+		for _, nodeIndex := range levelNodes {
+			siblingIndex := nodeIndex ^ 1
+			if !known[siblingIndex] {
+				hashes = append(hashes, t.nodes.Get(siblingIndex))
+			}
+			known[nodeIndex/2] = true
+		}
+		levelStart /= 2
+	}
+
+	return &MultiProof{
+		Indices:   indices,
+		NumLeaves: branches,
+		Hashes:    hashes,
+	}, nil
+}
+
+// VerifyMultiProof verifies a MultiProof that leafHashes (the hashed leaves,
+// in the same order as the indices used to generate the proof) are present
+// in the tree with the given root.
+func VerifyMultiProof(hash HashType, root []byte, leafHashes [][]byte, indices []uint64, proof *MultiProof) (bool, error) {
+	if len(leafHashes) != len(indices) {
+		return false, errors.New("leafHashes and indices must be the same length")
+	}
+
+	branches := proof.NumLeaves
+
+	known := make(map[uint64][]byte, len(indices))
+	for i, index := range indices {
+		known[branches+index] = leafHashes[i]
+	}
+
+	hashIdx := 0
+	levelStart := branches
+	for levelStart > 1 {
+		levelNodes := sortedKeysInHashMapRange(known, levelStart, levelStart*2)
+		for _, nodeIndex := range levelNodes {
+			siblingIndex := nodeIndex ^ 1
+			siblingHash, ok := known[siblingIndex]
+			if !ok {
+				if hashIdx >= len(proof.Hashes) {
+					return false, errors.New("proof is missing a sibling hash")
+				}
+				siblingHash = proof.Hashes[hashIdx]
+				hashIdx++
+			}
+
+			var combined []byte
+			if nodeIndex%2 == 0 {
+				combined = hash.Hash(known[nodeIndex], siblingHash)
+			} else {
+				combined = hash.Hash(siblingHash, known[nodeIndex])
+			}
+			known[nodeIndex/2] = combined
+		}
+		levelStart /= 2
+	}
+
+	return bytes.Equal(known[1], root), nil
+}
+
+// sortedKeysInRange returns the keys of set that fall within [lo, hi), in
+// ascending order.
+func sortedKeysInRange(set map[uint64]bool, lo, hi uint64) []uint64 {
+	keys := make([]uint64, 0)
+	for k := range set {
+		if k >= lo && k < hi {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// sortedKeysInHashMapRange returns the keys of set that fall within [lo, hi),
+// in ascending order.
+func sortedKeysInHashMapRange(set map[uint64][]byte, lo, hi uint64) []uint64 {
+	keys := make([]uint64, 0)
+	for k := range set {
+		if k >= lo && k < hi {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,44 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkletree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-merkletree/blake2b"
+)
+
+func TestMultiProofRoundTrip(t *testing.T) {
+	data := [][]byte{
+		[]byte("Foo"), []byte("Bar"), []byte("Baz"), []byte("Qux"), []byte("Quux"), []byte("Quuz"),
+	}
+	hash := blake2b.New()
+
+	tree, err := NewUsing(data, hash, false)
+	assert.Nil(t, err)
+
+	indices := []uint64{1, 3, 4}
+	proof, err := tree.GenerateMultiProof(indices)
+	assert.Nil(t, err)
+
+	leafHashes := make([][]byte, len(indices))
+	for i, index := range indices {
+		leafHashes[i] = leafHash(hash, data[index], int(index), false)
+	}
+
+	verified, err := VerifyMultiProof(hash, tree.Root(), leafHashes, indices, proof)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+}
@@ -0,0 +1,114 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkletree
+
+import (
+	"os"
+)
+
+// NodeStore is the interface used by MerkleTree to access the flat node
+// array underlying the tree. The default implementation keeps every node in
+// memory; FileNodeStore pages nodes from disk instead, for trees with too
+// many leaves to fit in RAM.
+type NodeStore interface {
+	// Get returns the hash stored at index.
+	Get(index uint64) []byte
+	// Set stores hash at index.
+	Set(index uint64, hash []byte)
+	// Len returns the number of node slots in the store.
+	Len() uint64
+	// Close releases any resources held by the store.
+	Close() error
+}
+
+// inMemoryNodeStore is the default NodeStore, backed by a plain slice.
+type inMemoryNodeStore struct {
+	nodes [][]byte
+}
+
+// newInMemoryNodeStore wraps an existing node slice as a NodeStore.
+func newInMemoryNodeStore(nodes [][]byte) *inMemoryNodeStore {
+	return &inMemoryNodeStore{nodes: nodes}
+}
+
+// Get returns the hash stored at index.
+func (s *inMemoryNodeStore) Get(index uint64) []byte {
+	return s.nodes[index]
+}
+
+// Set stores hash at index.
+func (s *inMemoryNodeStore) Set(index uint64, hash []byte) {
+	s.nodes[index] = hash
+}
+
+// Len returns the number of node slots in the store.
+func (s *inMemoryNodeStore) Len() uint64 {
+	return uint64(len(s.nodes))
+}
+
+// Close is a no-op for an in-memory store.
+func (s *inMemoryNodeStore) Close() error {
+	return nil
+}
+
+// FileNodeStore is a NodeStore that pages nodes from a fixed-record-length
+// file on disk rather than holding them all in memory, so a tree with tens
+// of millions of leaves can be built and queried without exhausting RAM.
+type FileNodeStore struct {
+	f          *os.File
+	hashLength uint64
+	len        uint64
+}
+
+// NewFileNodeStore opens (creating if necessary) a file-backed NodeStore
+// with room for length nodes of hashLength bytes each.
+func NewFileNodeStore(path string, length uint64, hashLength int) (*FileNodeStore, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Truncate(int64(length * uint64(hashLength))); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &FileNodeStore{
+		f:          f,
+		hashLength: uint64(hashLength),
+		len:        length,
+	}, nil
+}
+
+// Get returns the hash stored at index, reading it from disk.
+func (s *FileNodeStore) Get(index uint64) []byte {
+	buf := make([]byte, s.hashLength)
+	if _, err := s.f.ReadAt(buf, int64(index*s.hashLength)); err != nil {
+		return nil
+	}
+	return buf
+}
+
+// Set stores hash at index, writing it to disk.
+func (s *FileNodeStore) Set(index uint64, hash []byte) {
+	_, _ = s.f.WriteAt(hash, int64(index*s.hashLength))
+}
+
+// Len returns the number of node slots in the store.
+func (s *FileNodeStore) Len() uint64 {
+	return s.len
+}
+
+// Close closes the underlying file.
+func (s *FileNodeStore) Close() error {
+	return s.f.Close()
+}
@@ -0,0 +1,69 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkletree
+
+import "bytes"
+
+// Proof is a Merkle proof of inclusion, comprising the sibling hashes
+// encountered on the path from a leaf up to the root.
+type Proof struct {
+	// Hashes are the sibling hashes, ordered from the leaf's sibling to the
+	// root's sibling.
+	Hashes [][]byte
+	// Index is the index of the leaf within the tree.
+	Index uint64
+}
+
+// GenerateProof generates the Merkle proof for a piece of data that is
+// present in the tree.
+func (t *MerkleTree) GenerateProof(data []byte) (*Proof, error) {
+	index, err := t.indexOf(data)
+	if err != nil {
+		return nil, err
+	}
+
+	branches := t.nodes.Len() / 2
+	hashes := make([][]byte, 0)
+
+	nodeIndex := branches + index
+	for nodeIndex > 1 {
+		siblingIndex := nodeIndex ^ 1
+		hashes = append(hashes, t.nodes.Get(siblingIndex))
+		nodeIndex /= 2
+	}
+
+	return &Proof{
+		Hashes: hashes,
+		Index:  index,
+	}, nil
+}
+
+// VerifyProof verifies a Merkle proof of inclusion for the given data against
+// the given root, using the given hash type. Salt must match the value used
+// when the tree was built.
+func VerifyProof(hash HashType, salt bool, data []byte, proof *Proof, root []byte) (bool, error) {
+	node := leafHash(hash, data, int(proof.Index), salt)
+
+	index := proof.Index
+	for _, siblingHash := range proof.Hashes {
+		if index%2 == 0 {
+			node = hash.Hash(node, siblingHash)
+		} else {
+			node = hash.Hash(siblingHash, node)
+		}
+		index /= 2
+	}
+
+	return bytes.Equal(node, root), nil
+}
@@ -0,0 +1,128 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkletree
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/wealdtech/go-merkletree/blake2b"
+	"github.com/wealdtech/go-merkletree/keccak256"
+)
+
+// saveMagic identifies a saved tree file.
+var saveMagic = [4]byte{'M', 'R', 'K', 'L'}
+
+const (
+	hashTypeBLAKE2b   = 1
+	hashTypeKeccak256 = 2
+)
+
+// Save writes a compact binary representation of the tree to w: a magic
+// number, the hash type used to build it, whether its leaves were salted,
+// the number of leaves, and the node hashes themselves. The result can be
+// reopened with Load to generate proofs without rebuilding the tree.
+func (t *MerkleTree) Save(w io.Writer) error {
+	if err := t.requireStaticTree(); err != nil {
+		return err
+	}
+
+	hashTypeID, err := hashTypeToID(t.hash)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 4+1+1+8)
+	copy(header[0:4], saveMagic[:])
+	header[4] = hashTypeID
+	if t.salt {
+		header[5] = 1
+	}
+	binary.BigEndian.PutUint64(header[6:14], uint64(t.data))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	count := t.nodes.Len()
+	for i := uint64(1); i < count; i++ {
+		if _, err := w.Write(t.nodes.Get(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load reads a tree previously written by Save, reconstructing it with an
+// in-memory NodeStore.
+func Load(r io.Reader) (*MerkleTree, error) {
+	header := make([]byte, 4+1+1+8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if string(header[0:4]) != string(saveMagic[:]) {
+		return nil, errors.New("not a go-merkletree file")
+	}
+	hash, err := hashTypeFromID(header[4])
+	if err != nil {
+		return nil, err
+	}
+	salt := header[5] != 0
+	leafCount := binary.BigEndian.Uint64(header[6:14])
+
+	branches := nextPowOf2(leafCount)
+	nodes := make([][]byte, branches*2)
+	for i := range nodes {
+		if i == 0 {
+			continue
+		}
+		buf := make([]byte, hash.HashLength())
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		nodes[i] = buf
+	}
+
+	return &MerkleTree{
+		nodes: newInMemoryNodeStore(nodes),
+		data:  int(leafCount),
+		hash:  hash,
+		salt:  salt,
+	}, nil
+}
+
+// hashTypeToID maps a known HashType implementation to its on-disk
+// identifier.
+func hashTypeToID(hash HashType) (byte, error) {
+	switch hash.(type) {
+	case *blake2b.BLAKE2b:
+		return hashTypeBLAKE2b, nil
+	case *keccak256.Keccak256:
+		return hashTypeKeccak256, nil
+	default:
+		return 0, errors.New("hash type cannot be saved")
+	}
+}
+
+// hashTypeFromID maps an on-disk hash type identifier back to a HashType.
+func hashTypeFromID(id byte) (HashType, error) {
+	switch id {
+	case hashTypeBLAKE2b:
+		return blake2b.New(), nil
+	case hashTypeKeccak256:
+		return keccak256.New(), nil
+	default:
+		return nil, errors.New("unrecognised hash type id")
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-merkletree/blake2b"
+)
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	data := [][]byte{[]byte("Foo"), []byte("Bar"), []byte("Baz")}
+
+	tree, err := NewUsing(data, blake2b.New(), true)
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	assert.Nil(t, tree.Save(&buf))
+
+	loaded, err := Load(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, tree.Root(), loaded.Root())
+
+	proof, err := loaded.GenerateProof(data[1])
+	assert.Nil(t, err)
+
+	verified, err := VerifyProof(blake2b.New(), true, data[1], proof, loaded.Root())
+	assert.Nil(t, err)
+	assert.True(t, verified)
+}
@@ -0,0 +1,242 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+)
+
+// SparseTree is a Sparse Merkle Tree keyed by fixed-width byte keys. Unlike
+// MerkleTree, which only has as many leaves as it was given data, a
+// SparseTree has one leaf for every possible key of the given width; leaves
+// that have never been set are implicitly empty and their subtrees collapse
+// to precomputed zero hashes. This allows it to produce non-membership
+// proofs as well as membership proofs.
+type SparseTree struct {
+	// depth is the number of bits in a key, and so the number of levels
+	// between the root and a leaf.
+	depth int
+	// hash is the hash function used to build the tree.
+	hash HashType
+	// zeroHashes holds the hash of an empty subtree at each level, indexed
+	// from 0 (an empty leaf) to depth (the root of an empty tree). It is
+	// calculated once at construction so updates never need to recompute it.
+	zeroHashes [][]byte
+	// nodes holds every non-default node in the tree, keyed by its path from
+	// the root expressed as the key bits seen so far (MSB first) at that
+	// level. The root is stored under the empty path. Leaf nodes hold
+	// t.hash.Hash(value), not value itself; values are kept separately in
+	// leaves.
+	nodes map[string][]byte
+	// leaves holds the raw value set for each key that has one, keyed by the
+	// same full-depth path used for that key's leaf in nodes.
+	leaves map[string][]byte
+}
+
+// SparseProof is a proof of membership or non-membership of a key in a
+// SparseTree.
+type SparseProof struct {
+	// Value is the raw leaf value set for the key, or nil if the key is
+	// absent.
+	Value []byte
+	// Siblings holds the non-default sibling hashes encountered on the path
+	// from the leaf to the root, ordered from the leaf upwards.
+	Siblings [][]byte
+	// Bitmap has one bit per level, set when the corresponding sibling in
+	// Siblings is non-default; the bits are ordered from the leaf upwards,
+	// LSB first. Levels whose bit is clear have no entry in Siblings and are
+	// replaced with the cached zero-hash on verification.
+	Bitmap []byte
+}
+
+// NewSparse creates a new, empty SparseTree keyed by depth-bit keys using the
+// provided hash function.
+func NewSparse(depth int, hash HashType) (*SparseTree, error) {
+	if depth <= 0 {
+		return nil, errors.New("tree depth must be greater than 0")
+	}
+
+	zeroHashes := make([][]byte, depth+1)
+	zeroHashes[0] = make([]byte, hash.HashLength())
+	for i := 1; i <= depth; i++ {
+		zeroHashes[i] = hash.Hash(zeroHashes[i-1], zeroHashes[i-1])
+	}
+
+	return &SparseTree{
+		depth:      depth,
+		hash:       hash,
+		zeroHashes: zeroHashes,
+		nodes:      make(map[string][]byte),
+		leaves:     make(map[string][]byte),
+	}, nil
+}
+
+// Root returns the current root hash of the tree.
+func (t *SparseTree) Root() []byte {
+	if root, exists := t.nodes[""]; exists {
+		return root
+	}
+	return t.zeroHashes[t.depth]
+}
+
+// Update sets the leaf for key to value, recomputing only the nodes on the
+// path from the leaf to the root.
+func (t *SparseTree) Update(key []byte, value []byte) error {
+	if len(key)*8 < t.depth {
+		return errors.New("key is too short for tree depth")
+	}
+
+	path := pathString(key, t.depth)
+
+	t.leaves[path] = value
+	node := t.hash.Hash(value)
+	t.setNode(path, node)
+
+	for level := t.depth - 1; level >= 0; level-- {
+		prefix := path[:level]
+		bit := path[level]
+		siblingPrefix := prefix + flip(bit)
+
+		var left, right []byte
+		if bit == '0' {
+			left, right = node, t.nodeOrZero(siblingPrefix, t.depth-level-1)
+		} else {
+			left, right = t.nodeOrZero(siblingPrefix, t.depth-level-1), node
+		}
+		node = t.hash.Hash(left, right)
+		t.setNode(prefix, node)
+	}
+
+	return nil
+}
+
+// setNode records a non-default node, or removes the entry if it has
+// collapsed back to its subtree's zero hash.
+func (t *SparseTree) setNode(path string, hash []byte) {
+	if bytes.Equal(hash, t.zeroHashes[t.depth-len(path)]) {
+		delete(t.nodes, path)
+		return
+	}
+	t.nodes[path] = hash
+}
+
+// nodeOrZero returns the stored node at path, or the zero hash for a subtree
+// of the given height if no node is stored there.
+func (t *SparseTree) nodeOrZero(path string, height int) []byte {
+	if node, exists := t.nodes[path]; exists {
+		return node
+	}
+	return t.zeroHashes[height]
+}
+
+// Generate returns a proof of membership (if the key has a value) or
+// non-membership (if it does not) for key.
+func (t *SparseTree) Generate(key []byte) (*SparseProof, error) {
+	if len(key)*8 < t.depth {
+		return nil, errors.New("key is too short for tree depth")
+	}
+
+	path := pathString(key, t.depth)
+
+	siblings := make([][]byte, 0, t.depth)
+	bitmap := make([]byte, (t.depth+7)/8)
+
+	for level := t.depth - 1; level >= 0; level-- {
+		prefix := path[:level]
+		siblingPrefix := prefix + flip(path[level])
+		if sibling, exists := t.nodes[siblingPrefix]; exists {
+			siblings = append(siblings, sibling)
+			bitIndex := t.depth - 1 - level
+			bitmap[bitIndex/8] |= 1 << uint(bitIndex%8)
+		}
+	}
+
+	var value []byte
+	if leaf, exists := t.leaves[path]; exists {
+		value = leaf
+	}
+
+	return &SparseProof{
+		Value:    value,
+		Siblings: siblings,
+		Bitmap:   bitmap,
+	}, nil
+}
+
+// VerifyProof verifies a SparseProof of membership or non-membership for key
+// and value against root. It recomputes the path bottom-up, substituting the
+// tree's cached zero-hashes for any sibling the proof omits.
+func (t *SparseTree) VerifyProof(key []byte, value []byte, proof *SparseProof, root []byte) (bool, error) {
+	if len(key)*8 < t.depth {
+		return false, errors.New("key is too short for tree depth")
+	}
+
+	path := pathString(key, t.depth)
+
+	var node []byte
+	if value == nil {
+		node = t.zeroHashes[0]
+	} else {
+		node = t.hash.Hash(value)
+	}
+
+	siblingIdx := 0
+	for level := t.depth - 1; level >= 0; level-- {
+		height := t.depth - level - 1
+		bitIndex := t.depth - 1 - level
+		var sibling []byte
+		if proof.Bitmap[bitIndex/8]&(1<<uint(bitIndex%8)) != 0 {
+			if siblingIdx >= len(proof.Siblings) {
+				return false, errors.New("proof is missing a sibling hash")
+			}
+			sibling = proof.Siblings[siblingIdx]
+			siblingIdx++
+		} else {
+			sibling = t.zeroHashes[height]
+		}
+
+		if path[level] == '0' {
+			node = t.hash.Hash(node, sibling)
+		} else {
+			node = t.hash.Hash(sibling, node)
+		}
+	}
+
+	return bytes.Equal(node, root), nil
+}
+
+// pathString renders the top depth bits of key as a string of '0'/'1'
+// characters, MSB first, for use as a map key.
+func pathString(key []byte, depth int) string {
+	bits := make([]byte, depth)
+	for i := 0; i < depth; i++ {
+		byteIndex := i / 8
+		bitIndex := 7 - uint(i%8)
+		if key[byteIndex]&(1<<bitIndex) != 0 {
+			bits[i] = '1'
+		} else {
+			bits[i] = '0'
+		}
+	}
+	return string(bits)
+}
+
+// flip returns the opposite bit character to b.
+func flip(b byte) string {
+	if b == '0' {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,58 @@
+// Copyright © 2018, 2019 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkletree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-merkletree/blake2b"
+)
+
+func TestSparseMembership(t *testing.T) {
+	tree, err := NewSparse(8, blake2b.New())
+	assert.Nil(t, err)
+
+	key := []byte{0x2a}
+	value := []byte("value")
+	assert.Nil(t, tree.Update(key, value))
+
+	proof, err := tree.Generate(key)
+	assert.Nil(t, err)
+	assert.Equal(t, value, proof.Value)
+
+	verified, err := tree.VerifyProof(key, value, proof, tree.Root())
+	assert.Nil(t, err)
+	assert.True(t, verified)
+}
+
+func TestSparseNonMembership(t *testing.T) {
+	tree, err := NewSparse(8, blake2b.New())
+	assert.Nil(t, err)
+
+	assert.Nil(t, tree.Update([]byte{0x01}, []byte("value")))
+
+	absentKey := []byte{0x02}
+	proof, err := tree.Generate(absentKey)
+	assert.Nil(t, err)
+	assert.Nil(t, proof.Value)
+
+	verified, err := tree.VerifyProof(absentKey, nil, proof, tree.Root())
+	assert.Nil(t, err)
+	assert.True(t, verified)
+
+	verified, err = tree.VerifyProof(absentKey, []byte("wrong"), proof, tree.Root())
+	assert.Nil(t, err)
+	assert.False(t, verified)
+}